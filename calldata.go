@@ -0,0 +1,73 @@
+package anypack
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// PackMethod loads abiJSON, resolves method by name and builds the full
+// calldata for calling it with input: the method's 4-byte selector followed
+// by input packed via toArgs. input may be a map or a struct, resolved by
+// argument name the same way toArgs resolves it; a slice input is not
+// matched to the arguments by position, since toArgs hands the whole input
+// to each argument. opts configures name resolution and strictness,
+// defaulting to the zero Options value.
+func PackMethod(abiJSON io.Reader, method string, input any, opts ...Options) ([]byte, error) {
+	parsed, err := abi.JSON(abiJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed PackMethod: %w", err)
+	}
+
+	m, ok := parsed.Methods[method]
+	if !ok {
+		return nil, fmt.Errorf("failed PackMethod: method %q not found", method)
+	}
+
+	values, err := toArgs(m.Inputs, input, firstOption(opts))
+	if err != nil {
+		return nil, fmt.Errorf("failed PackMethod: %w", err)
+	}
+
+	packed, err := m.Inputs.Pack(values...)
+	if err != nil {
+		return nil, fmt.Errorf("failed PackMethod: %w", err)
+	}
+	return append(append([]byte{}, m.ID...), packed...), nil
+}
+
+// PackSignature builds the full calldata for a raw signature such as
+// "transfer(address,uint256)" without requiring a full ABI JSON document.
+// The selector is the first 4 bytes of keccak256(signature), so signature
+// must already be in its canonical form. opts configures name resolution
+// and strictness, defaulting to the zero Options value.
+func PackSignature(signature string, input any, opts ...Options) ([]byte, error) {
+	sel, err := abi.ParseSelector(signature)
+	if err != nil {
+		return nil, fmt.Errorf("failed PackSignature: %w", err)
+	}
+
+	args := make(abi.Arguments, len(sel.Inputs))
+	for i, in := range sel.Inputs {
+		typ, err := abi.NewType(in.Type, in.InternalType, in.Components)
+		if err != nil {
+			return nil, fmt.Errorf("failed PackSignature: %w", err)
+		}
+		args[i] = abi.Argument{Name: in.Name, Type: typ}
+	}
+
+	values, err := toArgs(args, input, firstOption(opts))
+	if err != nil {
+		return nil, fmt.Errorf("failed PackSignature: %w", err)
+	}
+
+	packed, err := args.Pack(values...)
+	if err != nil {
+		return nil, fmt.Errorf("failed PackSignature: %w", err)
+	}
+
+	selector := crypto.Keccak256([]byte(signature))[:4]
+	return append(selector, packed...), nil
+}
@@ -0,0 +1,62 @@
+package anypack
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestPackMethod(t *testing.T) {
+	input := map[string]any{
+		"to":     "0x1111111111111111111111111111111111111111",
+		"amount": "1000000000000000000",
+	}
+
+	calldata, err := PackMethod(strings.NewReader(erc20TransferABI), "transfer", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := abi.JSON(strings.NewReader(erc20TransferABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	method := parsed.Methods["transfer"]
+	if !strings.HasPrefix(common.Bytes2Hex(calldata), common.Bytes2Hex(method.ID)) {
+		t.Errorf("calldata %x does not start with selector %x", calldata, method.ID)
+	}
+
+	values, err := method.Inputs.UnpackValues(calldata[4:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := values[0].(common.Address); got != common.HexToAddress(input["to"].(string)) {
+		t.Errorf("to = %v, want %v", got, input["to"])
+	}
+}
+
+func TestPackMethodUnknownMethod(t *testing.T) {
+	if _, err := PackMethod(strings.NewReader(erc20TransferABI), "nope", nil); err == nil {
+		t.Fatal("expected error for unknown method")
+	}
+}
+
+func TestPackSignature(t *testing.T) {
+	input := map[string]any{
+		"name0": "0x1111111111111111111111111111111111111111",
+		"name1": "1000000000000000000",
+	}
+
+	calldata, err := PackSignature("transfer(address,uint256)", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantSelector := crypto.Keccak256([]byte("transfer(address,uint256)"))[:4]
+	if !strings.HasPrefix(common.Bytes2Hex(calldata), common.Bytes2Hex(wantSelector)) {
+		t.Errorf("calldata %x does not start with selector %x", calldata, wantSelector)
+	}
+}
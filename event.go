@@ -0,0 +1,98 @@
+package anypack
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// EncodeEvent builds the topics and data for an EVM log matching event,
+// filling in indexed and non-indexed arguments from input the same flexible
+// way toArgs does. Topic 0 is keccak256(event signature) unless event is
+// anonymous. Indexed elementary values are left-padded to 32 bytes via
+// go-ethereum's MakeTopics, which also keccak256-hashes string and []byte
+// values; indexed arrays and tuples are reference types that MakeTopics
+// can't hash (it only special-cases string/[]byte), so those are hashed here
+// instead, as keccak256 of the argument packed on its own per Solidity's
+// indexed-event encoding rules. opts configures name resolution and
+// strictness, defaulting to the zero Options value.
+func EncodeEvent(event abi.Event, input any, opts ...Options) (topics []common.Hash, data []byte, err error) {
+	opt := firstOption(opts)
+	if !event.Anonymous {
+		topics = append(topics, event.ID)
+	}
+
+	for i, arg := range event.Inputs {
+		if !arg.Indexed {
+			continue
+		}
+		v, ok := toArg(arg.Name, arg.Type, input, opt)
+		if !ok {
+			return nil, nil, fmt.Errorf("failed EncodeEvent: indexed arg %d, %s, %s", i, arg.Name, arg.Type.String())
+		}
+
+		switch arg.Type.T {
+		case abi.TupleTy, abi.SliceTy, abi.ArrayTy:
+			packed, err := abi.Arguments{{Type: arg.Type}}.Pack(v)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed EncodeEvent: indexed arg %d, %s: %w", i, arg.Name, err)
+			}
+			topics = append(topics, crypto.Keccak256Hash(packed))
+		default:
+			rules, err := abi.MakeTopics([]any{v})
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed EncodeEvent: %w", err)
+			}
+			topics = append(topics, rules[0][0])
+		}
+	}
+
+	nonIndexed := event.Inputs.NonIndexed()
+	values, err := toArgs(nonIndexed, input, opt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed EncodeEvent: %w", err)
+	}
+	if data, err = nonIndexed.Pack(values...); err != nil {
+		return nil, nil, fmt.Errorf("failed EncodeEvent: %w", err)
+	}
+	return topics, data, nil
+}
+
+// DecodeLog converts an EVM log emitted by event back into a map keyed by
+// argument name. Indexed elementary values are reconstructed from their
+// topic; indexed dynamic values (string, bytes, arrays) only yield their
+// topic hash, since the original value cannot be recovered from it.
+// Non-indexed values are decoded via Unpack.
+func DecodeLog(event abi.Event, log types.Log) (map[string]any, error) {
+	topics := log.Topics
+	if !event.Anonymous {
+		if len(topics) == 0 {
+			return nil, fmt.Errorf("failed DecodeLog: missing signature topic")
+		}
+		topics = topics[1:]
+	}
+
+	indexed := make(abi.Arguments, 0, len(event.Inputs))
+	for _, arg := range event.Inputs {
+		if arg.Indexed {
+			indexed = append(indexed, arg)
+		}
+	}
+
+	out := make(map[string]any, len(event.Inputs))
+	if err := abi.ParseTopicsIntoMap(out, indexed, topics); err != nil {
+		return nil, fmt.Errorf("failed DecodeLog: %w", err)
+	}
+
+	values, err := Unpack(event.Inputs.NonIndexed(), log.Data, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed DecodeLog: %w", err)
+	}
+	for name, v := range values {
+		out[name] = v
+	}
+	return out, nil
+}
@@ -0,0 +1,109 @@
+package anypack
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func transferEvent(t *testing.T) abi.Event {
+	t.Helper()
+	addrTyp, err := abi.NewType("address", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	amountTyp, err := abi.NewType("uint256", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs := abi.Arguments{
+		{Name: "from", Type: addrTyp, Indexed: true},
+		{Name: "to", Type: addrTyp, Indexed: true},
+		{Name: "amount", Type: amountTyp},
+	}
+	return abi.NewEvent("Transfer", "Transfer", false, inputs)
+}
+
+func TestEncodeEventDecodeLogRoundTrip(t *testing.T) {
+	event := transferEvent(t)
+	from := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	input := map[string]any{
+		"from":   from,
+		"to":     to,
+		"amount": "1000",
+	}
+
+	topics, data, err := EncodeEvent(event, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(topics) != 3 {
+		t.Fatalf("len(topics) = %d, want 3", len(topics))
+	}
+	if topics[0] != event.ID {
+		t.Errorf("topics[0] = %v, want event ID %v", topics[0], event.ID)
+	}
+
+	values, err := DecodeLog(event, types.Log{Topics: topics, Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := values["from"].(common.Address); !ok || got != from {
+		t.Errorf("values[\"from\"] = %v, want %v", values["from"], from)
+	}
+	if got, ok := values["to"].(common.Address); !ok || got != to {
+		t.Errorf("values[\"to\"] = %v, want %v", values["to"], to)
+	}
+	if got, ok := values["amount"].(*big.Int); !ok || got.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("values[\"amount\"] = %v, want 1000", values["amount"])
+	}
+}
+
+func TestDecodeLogMissingSignatureTopic(t *testing.T) {
+	event := transferEvent(t)
+	if _, err := DecodeLog(event, types.Log{}); err == nil {
+		t.Fatal("expected error for log with no topics")
+	}
+}
+
+// TestEncodeEventIndexedDynamicArray covers an indexed uint256[] argument, a
+// reference type abi.MakeTopics can't hash on its own (it only special-cases
+// string and []byte, and otherwise rejects anything that isn't a Go byte
+// array with "unsupported indexed type"). EncodeEvent must instead hash the
+// argument's own ABI-packed encoding, matching go-ethereum's Pack output for
+// the same value.
+func TestEncodeEventIndexedDynamicArray(t *testing.T) {
+	amountsTyp, err := abi.NewType("uint256[]", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs := abi.Arguments{
+		{Name: "amounts", Type: amountsTyp, Indexed: true},
+	}
+	event := abi.NewEvent("Batch", "Batch", false, inputs)
+
+	input := map[string]any{
+		"amounts": []any{"1000", "2000"},
+	}
+	topics, _, err := EncodeEvent(event, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(topics) != 2 {
+		t.Fatalf("len(topics) = %d, want 2", len(topics))
+	}
+
+	packed, err := abi.Arguments{{Type: amountsTyp}}.Pack([]*big.Int{big.NewInt(1000), big.NewInt(2000)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := crypto.Keccak256Hash(packed)
+	if topics[1] != want {
+		t.Errorf("topics[1] = %v, want %v", topics[1], want)
+	}
+}
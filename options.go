@@ -0,0 +1,42 @@
+package anypack
+
+import "reflect"
+
+// Options configures how toArgs/toArg and their compiled plans resolve ABI argument/tuple
+// field names onto Go struct fields and map keys, and how they treat values
+// that the default behavior silently skips.
+type Options struct {
+	// NameResolver, when set, overrides the default abi/json tag and
+	// abi.ToCamelCase name resolution. It receives the struct value being
+	// read from and the raw ABI parameter/tuple field name, and returns the
+	// matching field plus whether a match was found.
+	NameResolver func(value reflect.Value, name string) (reflect.Value, bool)
+
+	// CaseSensitive requires exact matches for abi/json tag and map key
+	// lookups, which are case-insensitive by default. The abi.ToCamelCase
+	// struct field name fallback always matches exactly.
+	CaseSensitive bool
+
+	// Strict turns the default "skip" behavior for nil pointers, funcs and
+	// channels into a hard error instead.
+	Strict bool
+
+	// MaxStringLen, when non-zero, rejects StringTy inputs longer than this
+	// many bytes. On-chain strings are unbounded by default, so the zero
+	// value imposes no limit; callers that want go-ethereum's historical
+	// 32-byte sanity check can set MaxStringLen: 32.
+	MaxStringLen int
+
+	// PadFixedBytes left-pads hex string inputs to FixedBytesTy arguments
+	// that decode to fewer than the target size, instead of rejecting them.
+	PadFixedBytes bool
+}
+
+// firstOption returns opts[0] if present, otherwise the zero Options value,
+// which selects the default behavior described on each Options field.
+func firstOption(opts []Options) Options {
+	if len(opts) > 0 {
+		return opts[0]
+	}
+	return Options{}
+}
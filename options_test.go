@@ -0,0 +1,115 @@
+package anypack
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestToArgsStructTagResolution(t *testing.T) {
+	type transferReq struct {
+		Dest   string `abi:"to"`
+		Amount string `json:"amount"`
+	}
+	input := transferReq{
+		Dest:   "0x1111111111111111111111111111111111111111",
+		Amount: "1000",
+	}
+
+	args := transferArgs(t)
+	values, err := toArgs(args, input, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := values[0].(common.Address); got != common.HexToAddress(input.Dest) {
+		t.Errorf("values[0] = %v, want %v", got, input.Dest)
+	}
+}
+
+func TestToArgsCaseSensitive(t *testing.T) {
+	args := transferArgs(t)
+	input := map[string]any{
+		"TO":     "0x1111111111111111111111111111111111111111",
+		"AMOUNT": "1000",
+	}
+
+	if _, err := toArgs(args, input, Options{}); err != nil {
+		t.Fatalf("case-insensitive lookup unexpectedly failed: %v", err)
+	}
+	if _, err := toArgs(args, input, Options{CaseSensitive: true}); err == nil {
+		t.Fatal("expected error for case-sensitive lookup against mismatched map keys")
+	}
+}
+
+func TestToArgsStrictRejectsNilPointer(t *testing.T) {
+	type transferReq struct {
+		To     *string
+		Amount string
+	}
+	args := transferArgs(t)
+	input := transferReq{To: nil, Amount: "1000"}
+
+	values, err := toArgs(args, input, Options{})
+	if err != nil {
+		t.Fatalf("non-strict nil pointer unexpectedly failed: %v", err)
+	}
+	if values[0] != nil {
+		t.Errorf("values[0] = %v, want nil (skipped)", values[0])
+	}
+	if _, err := toArgs(args, input, Options{Strict: true}); err == nil {
+		t.Fatal("expected error for nil pointer input under Strict")
+	}
+}
+
+func TestToArgsNameResolverOverride(t *testing.T) {
+	type weird struct {
+		Field1 string
+		Field2 string
+	}
+	input := weird{Field1: "0x1111111111111111111111111111111111111111", Field2: "1000"}
+
+	resolver := func(value reflect.Value, name string) (reflect.Value, bool) {
+		switch name {
+		case "to":
+			return value.FieldByName("Field1"), true
+		case "amount":
+			return value.FieldByName("Field2"), true
+		default:
+			return reflect.Value{}, false
+		}
+	}
+
+	args := transferArgs(t)
+	values, err := toArgs(args, input, Options{NameResolver: resolver})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := values[0].(common.Address); got != common.HexToAddress(input.Field1) {
+		t.Errorf("values[0] = %v, want %v", got, input.Field1)
+	}
+}
+
+func TestAbiTagTakesPrecedenceOverJSONTag(t *testing.T) {
+	type conflicting struct {
+		Other string `json:"to"`
+		Dest  string `abi:"to"`
+	}
+	addrTyp, err := abi.NewType("address", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := conflicting{
+		Other: "0x2222222222222222222222222222222222222222",
+		Dest:  "0x1111111111111111111111111111111111111111",
+	}
+	got, ok := toArg("to", addrTyp, input, Options{})
+	if !ok {
+		t.Fatal("toArg failed")
+	}
+	if got.(common.Address) != common.HexToAddress("0x1111111111111111111111111111111111111111") {
+		t.Errorf("toArg = %v, want resolved via abi tag", got)
+	}
+}
@@ -0,0 +1,421 @@
+package anypack
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// Packing the same method thousands of times per second redoes the same
+// struct-tag scans, map-key scans, reflect.ArrayOf constructions and
+// abiTyp.T switches on every call. compilePlanCached turns a given
+// (abi.Arguments, input reflect.Type) pair into a compiledPlan once: each
+// leaf's struct-field index, map-key name and abiTyp.T-driven converter are
+// resolved a single time and stored as closures, so toArgs replays the plan
+// on every later call instead of re-walking tags, re-scanning map keys, or
+// re-running the type switch.
+//
+// Inputs built from map[string]any necessarily keep one dynamic step, since
+// a map value's concrete type (string vs *big.Int vs common.Address, ...) is
+// only known once a value actually arrives; nested tuple/slice plans for
+// those leaves are compiled lazily on first sight of a concrete shape and
+// cached per shape thereafter, so even map-driven calls reach steady state
+// after their first pack. Struct inputs carry their field types statically,
+// so their plans (and any tuples/slices nested inside them) compile fully up
+// front.
+//
+// This only pays off across calls that reuse the same abi.Arguments value,
+// e.g. a server that parses a method once via abi.JSON and packs it
+// repeatedly. PackMethod and PackSignature build a fresh abi.Arguments from
+// their own re-parse on every call, so calling them in a loop never hits the
+// cache; maxPlanCacheEntries bounds planCache so that pattern grows it to a
+// cap and then stops, rather than retaining one permanent entry (pinning its
+// abi.Arguments backing array alive) per call forever.
+
+var planCache sync.Map // map[planCacheKey]compiledPlan
+
+// planCacheSize tracks (approximately) how many entries are in planCache.
+// sync.Map has no O(1) length, so this is kept alongside it instead.
+var planCacheSize int64
+
+// maxPlanCacheEntries bounds planCache so a caller that never reuses the
+// same abi.Arguments (PackMethod, PackSignature) can't grow it without
+// limit; callers that do reuse abi.Arguments realistically compile a small,
+// fixed number of distinct (abi.Arguments, input type) plans, well under
+// this cap.
+const maxPlanCacheEntries = 4096
+
+// planCacheKey identifies a compiled plan. abi.Arguments itself isn't
+// comparable (it embeds slices), so the plan is keyed by the identity of its
+// backing array (a pointer to its first element) plus its length, rather
+// than a signature built from its contents — that keeps compilePlanCached
+// itself allocation-free on the steady-state path, at the cost of only
+// caching across calls that reuse the same abi.Arguments value (e.g. a
+// server that parses a method once via abi.JSON and packs it repeatedly),
+// which is exactly the hot path this cache targets. CaseSensitive and
+// whether a NameResolver is set affect how names resolve onto inputType, so
+// they are part of the key too.
+type planCacheKey struct {
+	argsPtr       *abi.Argument
+	argsLen       int
+	inputType     reflect.Type
+	caseSensitive bool
+	hasResolver   bool
+}
+
+// argsIdentity returns the (pointer, length) pair identifying abiArgs'
+// backing array, or (nil, 0) for an empty abiArgs.
+func argsIdentity(abiArgs abi.Arguments) (*abi.Argument, int) {
+	if len(abiArgs) == 0 {
+		return nil, 0
+	}
+	return &abiArgs[0], len(abiArgs)
+}
+
+// compilePlanCached returns the compiledPlan for (abiArgs, inputType, opts),
+// building and storing it on first use.
+func compilePlanCached(abiArgs abi.Arguments, inputType reflect.Type, opts Options) compiledPlan {
+	argsPtr, argsLen := argsIdentity(abiArgs)
+	key := planCacheKey{
+		argsPtr:       argsPtr,
+		argsLen:       argsLen,
+		inputType:     inputType,
+		caseSensitive: opts.CaseSensitive,
+		hasResolver:   opts.NameResolver != nil,
+	}
+	if cached, ok := planCache.Load(key); ok {
+		return cached.(compiledPlan)
+	}
+	plan := compilePlan(abiArgs, inputType, opts)
+	if atomic.AddInt64(&planCacheSize, 1) <= maxPlanCacheEntries {
+		planCache.Store(key, plan)
+	} else {
+		atomic.AddInt64(&planCacheSize, -1)
+	}
+	return plan
+}
+
+// compiledPlan is one compiled leaf per top-level argument or tuple field.
+type compiledPlan []compiledLeaf
+
+// accessFunc resolves an argument/tuple field name onto a value inside
+// container. It is chosen once per leaf at compile time based on
+// container's static Go type, so the per-call cost is a direct field index
+// or a single cached map lookup rather than a fresh tag/key scan.
+type accessFunc func(container reflect.Value, opts Options) (reflect.Value, bool)
+
+// convertFunc turns an already-resolved Go value into the any that
+// abi.Arguments.Pack expects for one ABI type. It is chosen once per leaf
+// from the abiTyp.T switch at compile time (see compileConvert), so packing
+// never re-runs that switch.
+type convertFunc func(value reflect.Value, opts Options) (any, bool)
+
+// compiledLeaf is the compiled counterpart of one toArg call.
+type compiledLeaf struct {
+	name    string
+	access  accessFunc
+	convert convertFunc
+}
+
+// run replays what toArg does for this leaf against container: skip
+// func/chan containers and nil-pointer results (or fail under opts.Strict),
+// fail on an unresolved name, and otherwise hand the resolved value to the
+// precompiled converter.
+func (leaf compiledLeaf) run(container reflect.Value, opts Options) (any, bool) {
+	value := container
+	if leaf.name != "" {
+		switch container.Kind() {
+		case reflect.Func, reflect.Chan:
+			if opts.Strict {
+				return nil, false
+			}
+			return nil, true // skip
+		default:
+			resolved, ok := leaf.access(container, opts)
+			if !ok {
+				return nil, false
+			}
+			value = resolved
+		}
+	}
+
+	if value.Kind() == reflect.Invalid {
+		return nil, false
+	}
+	if value.Kind() == reflect.Pointer && value.IsNil() {
+		if opts.Strict {
+			return nil, false
+		}
+		return nil, true // skip
+	}
+	return leaf.convert(value, opts)
+}
+
+// compilePlan builds a compiledPlan for abiArgs read off a container of
+// inputType. inputType may be nil when the container's concrete type is
+// only known at call time (a tuple/slice nested inside a map[string]any
+// value); leaves then fall back to resolving names dynamically.
+func compilePlan(abiArgs abi.Arguments, inputType reflect.Type, opts Options) compiledPlan {
+	plan := make(compiledPlan, len(abiArgs))
+	for i, arg := range abiArgs {
+		plan[i] = compiledLeaf{
+			name:    arg.Name,
+			access:  compileAccess(arg.Name, inputType, opts),
+			convert: compileConvert(arg.Type, leafInputType(inputType, arg.Name, opts), opts),
+		}
+	}
+	return plan
+}
+
+// leafInputType returns the static Go type a field/key named name will hold
+// within a container of containerType, or nil when that type is only known
+// once a value actually arrives (containerType itself unknown, the field's
+// declared type is an interface, or a custom NameResolver makes the field
+// unpredictable).
+func leafInputType(containerType reflect.Type, name string, opts Options) reflect.Type {
+	if containerType == nil {
+		return nil
+	}
+	switch containerType.Kind() {
+	case reflect.Struct:
+		if opts.NameResolver != nil {
+			return nil
+		}
+		idx, ok := cachedFieldIndex(containerType, name, opts.CaseSensitive)
+		if !ok {
+			return nil
+		}
+		fieldType := containerType.FieldByIndex(idx).Type
+		if fieldType.Kind() == reflect.Interface {
+			return nil
+		}
+		return fieldType
+	case reflect.Map:
+		elemType := containerType.Elem()
+		if elemType.Kind() == reflect.Interface {
+			return nil
+		}
+		return elemType
+	default:
+		return nil
+	}
+}
+
+// compileAccess chooses how a leaf named name resolves itself against a
+// container of containerType. Struct containers get a precomputed field
+// index (skipping cachedFieldIndex's map lookup on every call); map
+// containers get their camelCase fallback precomputed once; anything else
+// (including containerType == nil, meaning the concrete type is only known
+// once a value arrives) falls back to resolving dynamically per call.
+func compileAccess(name string, containerType reflect.Type, opts Options) accessFunc {
+	if name == "" {
+		return identityAccess
+	}
+	if containerType == nil {
+		return dynamicAccess(name, opts)
+	}
+
+	switch containerType.Kind() {
+	case reflect.Struct:
+		if opts.NameResolver != nil {
+			return func(container reflect.Value, opts Options) (reflect.Value, bool) {
+				return opts.NameResolver(container, name)
+			}
+		}
+		idx, ok := cachedFieldIndex(containerType, name, opts.CaseSensitive)
+		if !ok {
+			return neverFoundAccess
+		}
+		return func(container reflect.Value, _ Options) (reflect.Value, bool) {
+			return container.FieldByIndex(idx), true
+		}
+
+	case reflect.Map:
+		camelName := abi.ToCamelCase(name)
+		return func(container reflect.Value, opts Options) (reflect.Value, bool) {
+			return mapAccessOrContainer(container, name, camelName, opts.CaseSensitive)
+		}
+
+	default:
+		return identityAccess
+	}
+}
+
+// mapAccessOrContainer resolves name (or its camelCase form) against
+// container's keys same as resolveMapKey did, but falls back to container
+// itself on a miss rather than failing — this is what lets a tuple
+// argument's fields resolve directly off a flat parent map when the map has
+// no wrapper key matching the tuple's own name.
+func mapAccessOrContainer(container reflect.Value, name, camelName string, caseSensitive bool) (reflect.Value, bool) {
+	if v, ok := cachedMapKey(container, name, camelName, caseSensitive); ok {
+		return v, true
+	}
+	return container, true
+}
+
+func identityAccess(container reflect.Value, _ Options) (reflect.Value, bool) {
+	return container, true
+}
+
+func neverFoundAccess(reflect.Value, Options) (reflect.Value, bool) {
+	return reflect.Value{}, false
+}
+
+// dynamicAccess is compileAccess's fallback for containers whose concrete
+// type is only known once a value arrives (e.g. a tuple stored inside a
+// map[string]any). It reproduces resolveField/resolveMapKey's per-call
+// resolution, still going through the field-index/map-key caches so repeat
+// calls against the same concrete shape skip the underlying scans.
+func dynamicAccess(name string, opts Options) accessFunc {
+	camelName := abi.ToCamelCase(name)
+	return func(container reflect.Value, opts Options) (reflect.Value, bool) {
+		switch container.Kind() {
+		case reflect.Struct:
+			if opts.NameResolver != nil {
+				return opts.NameResolver(container, name)
+			}
+			idx, ok := cachedFieldIndex(container.Type(), name, opts.CaseSensitive)
+			if !ok {
+				return reflect.Value{}, false
+			}
+			return container.FieldByIndex(idx), true
+		case reflect.Map:
+			return mapAccessOrContainer(container, name, camelName, opts.CaseSensitive)
+		default:
+			return container, true
+		}
+	}
+}
+
+var fieldIndexCache sync.Map // map[fieldIndexKey]fieldIndexEntry
+
+type fieldIndexKey struct {
+	typ           reflect.Type
+	name          string
+	caseSensitive bool
+}
+
+// fieldIndexEntry distinguishes "not found" from "found at index []" so a
+// cached negative result doesn't read back as a zero-length, but present,
+// index path via a bare type assertion on a nil []int.
+type fieldIndexEntry struct {
+	idx   []int
+	found bool
+}
+
+// cachedFieldIndex resolves name onto a field index path of typ, using
+// fieldIndexCache to avoid re-scanning struct tags on repeated calls.
+func cachedFieldIndex(typ reflect.Type, name string, caseSensitive bool) ([]int, bool) {
+	key := fieldIndexKey{typ, name, caseSensitive}
+	if cached, ok := fieldIndexCache.Load(key); ok {
+		entry := cached.(fieldIndexEntry)
+		return entry.idx, entry.found
+	}
+
+	idx, ok := scanFieldIndex(typ, name, abi.ToCamelCase(name), caseSensitive)
+	fieldIndexCache.Store(key, fieldIndexEntry{idx, ok})
+	return idx, ok
+}
+
+// scanFieldIndex is the uncached struct-tag scan: it prefers an explicit
+// `abi:"name"` tag, falls back to `json:"name"`, and finally to the
+// abi.ToCamelCase(name) convention.
+func scanFieldIndex(typ reflect.Type, name, camelName string, caseSensitive bool) ([]int, bool) {
+	for _, tagKey := range [...]string{"abi", "json"} {
+		for i := 0; i < typ.NumField(); i++ {
+			tag, ok := typ.Field(i).Tag.Lookup(tagKey)
+			if !ok {
+				continue
+			}
+			tag, _, _ = strings.Cut(tag, ",")
+			if sameName(tag, name, caseSensitive) {
+				return []int{i}, true
+			}
+		}
+	}
+
+	if field, ok := typ.FieldByName(camelName); ok {
+		return field.Index, true
+	}
+	return nil, false
+}
+
+var mapKeyCache sync.Map // map[mapKeyCacheKey]string
+
+type mapKeyCacheKey struct {
+	typ           reflect.Type
+	name          string
+	caseSensitive bool
+}
+
+// cachedMapKey resolves name (or its camelCase form) against the keys
+// actually present in value, caching the winning key string per map type so
+// repeated packs skip the MapKeys scan and index the map directly.
+func cachedMapKey(value reflect.Value, name, camelName string, caseSensitive bool) (reflect.Value, bool) {
+	key := mapKeyCacheKey{value.Type(), name, caseSensitive}
+	if cached, ok := mapKeyCache.Load(key); ok {
+		matched := cached.(string)
+		if v := value.MapIndex(reflect.ValueOf(matched)); v.IsValid() {
+			return v, true
+		}
+		// Cached key no longer present in this particular map; fall through
+		// to a fresh scan rather than silently dropping the field.
+	}
+
+	for _, mapKey := range value.MapKeys() {
+		k := mapKey.String()
+		if sameName(k, name, caseSensitive) || sameName(k, camelName, caseSensitive) {
+			mapKeyCache.Store(key, k)
+			return value.MapIndex(mapKey), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+var byteArrayTypeCache sync.Map // map[int]reflect.Type
+
+// byteArrayType returns the reflect.Type for [size]byte, caching the
+// reflect.ArrayOf construction per size.
+func byteArrayType(size int) reflect.Type {
+	if cached, ok := byteArrayTypeCache.Load(size); ok {
+		return cached.(reflect.Type)
+	}
+	typ := reflect.ArrayOf(size, reflect.TypeOf(uint8(0)))
+	byteArrayTypeCache.Store(size, typ)
+	return typ
+}
+
+var sliceTypeCache sync.Map // map[reflect.Type]reflect.Type
+
+// cachedSliceType returns the Go slice type packing elemTyp elements into,
+// caching the reflect.SliceOf construction per element Go type so repeated
+// SliceTy/ArrayTy leaves skip rebuilding it. It keys on elemTyp's own Go
+// type (abiTyp.TupleType for tuples, abiTyp.GetType() otherwise) rather
+// than abiTyp.String(): two TupleTy types with the same element types but
+// different field names stringify identically (e.g. both "(uint256,
+// address)"), which would otherwise collide and hand one tuple's slice
+// element type to the other. reflect canonicalizes structurally identical
+// composite types to the same Type value, and go-ethereum's generated
+// TupleType struct fields are named after the tuple's own field names, so
+// this key is unique per distinct tuple shape.
+func cachedSliceType(elemTyp abi.Type) reflect.Type {
+	key := elemGoType(elemTyp)
+	if cached, ok := sliceTypeCache.Load(key); ok {
+		return cached.(reflect.Type)
+	}
+	typ := toSliceTyp(elemTyp)
+	sliceTypeCache.Store(key, typ)
+	return typ
+}
+
+// elemGoType returns the Go type toSliceTyp would build a slice of, without
+// actually building the slice type, for use as cachedSliceType's cache key.
+func elemGoType(elemTyp abi.Type) reflect.Type {
+	if elemTyp.T == abi.TupleTy {
+		return elemTyp.TupleType
+	}
+	return elemTyp.GetType()
+}
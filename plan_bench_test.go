@@ -0,0 +1,204 @@
+package anypack
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// erc20TransferABI is a representative flat method: two leaf arguments, no
+// tuples, exercising the map-key cache.
+const erc20TransferABI = `[{
+	"name": "transfer",
+	"type": "function",
+	"inputs": [
+		{"name": "to", "type": "address"},
+		{"name": "amount", "type": "uint256"}
+	]
+}]`
+
+// nestedTupleSwapABI is a representative nested-tuple method, exercising the
+// field-index cache across both levels of nested tuple plans.
+const nestedTupleSwapABI = `[{
+	"name": "swap",
+	"type": "function",
+	"inputs": [{
+		"name": "params",
+		"type": "tuple",
+		"components": [
+			{"name": "tokenIn", "type": "address"},
+			{"name": "tokenOut", "type": "address"},
+			{
+				"name": "fee",
+				"type": "tuple",
+				"components": [
+					{"name": "amount", "type": "uint256"},
+					{"name": "recipient", "type": "address"}
+				]
+			}
+		]
+	}]
+}]`
+
+type swapFee struct {
+	Amount    string
+	Recipient string
+}
+
+type swapParams struct {
+	TokenIn  string
+	TokenOut string
+	Fee      swapFee
+}
+
+type swapInput struct {
+	Params swapParams
+}
+
+// mustMethodInputs parses abiJSON and returns the named method's Inputs, for
+// use as fixed setup done once outside a benchmark's timed loop (or shared
+// with plan_test.go's compiled-plan tests, which want the same fixtures).
+func mustMethodInputs(tb testing.TB, abiJSON, method string) abi.Arguments {
+	tb.Helper()
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	m, ok := parsed.Methods[method]
+	if !ok {
+		tb.Fatalf("method %q not found", method)
+	}
+	return m.Inputs
+}
+
+// BenchmarkToArgs_ERC20Transfer packs a flat two-argument method from a map
+// input, the steady-state hot path for RPC clients calling the same method
+// repeatedly. ABI parsing happens once, outside the timed loop, so the
+// measurement reflects toArgs alone.
+func BenchmarkToArgs_ERC20Transfer(b *testing.B) {
+	inputs := mustMethodInputs(b, erc20TransferABI, "transfer")
+	input := map[string]any{
+		"to":     "0x1111111111111111111111111111111111111111",
+		"amount": "1000000000000000000",
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := toArgs(inputs, input, Options{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkToArgs_NestedTupleSwap packs a nested-tuple method from a struct
+// input, exercising the compiled plan's field-index access and nested tuple
+// sub-plans across two levels of tuple. ABI parsing happens once, outside
+// the timed loop.
+func BenchmarkToArgs_NestedTupleSwap(b *testing.B) {
+	inputs := mustMethodInputs(b, nestedTupleSwapABI, "swap")
+	input := swapInput{
+		Params: swapParams{
+			TokenIn:  "0x1111111111111111111111111111111111111111",
+			TokenOut: "0x2222222222222222222222222222222222222222",
+			Fee: swapFee{
+				Amount:    "500",
+				Recipient: "0x3333333333333333333333333333333333333333",
+			},
+		},
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := toArgs(inputs, input, Options{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// The pair below isolates the underlying field-index and map-key caches
+// compilePlan builds its access funcs on top of, by benchmarking the cached
+// entry points against the uncached scans they wrap. The compiled plan
+// itself goes further than these two caches alone: it also resolves struct
+// leaves straight to a field index with no cache lookup at all (see
+// compileAccess), and compiles the abiTyp.T switch once per leaf instead of
+// re-running it on every toArgs call (see compileConvert).
+
+type cachedFieldBenchTarget struct {
+	TokenIn  string
+	TokenOut string
+	Fee      swapFee
+}
+
+// BenchmarkFieldResolution_Uncached resolves a struct field name via the
+// raw tag/camelCase scan (scanFieldIndex) on every call, with no cache.
+func BenchmarkFieldResolution_Uncached(b *testing.B) {
+	typ := reflect.TypeOf(cachedFieldBenchTarget{})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, ok := scanFieldIndex(typ, "fee", "Fee", false); !ok {
+			b.Fatal("field not found")
+		}
+	}
+}
+
+// BenchmarkFieldResolution_Cached resolves the same struct field name via
+// cachedFieldIndex, which scans once and serves every later call from
+// fieldIndexCache.
+func BenchmarkFieldResolution_Cached(b *testing.B) {
+	typ := reflect.TypeOf(cachedFieldBenchTarget{})
+	if _, ok := cachedFieldIndex(typ, "fee", false); !ok {
+		b.Fatal("field not found")
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, ok := cachedFieldIndex(typ, "fee", false); !ok {
+			b.Fatal("field not found")
+		}
+	}
+}
+
+// rawMapKeyScan is the uncached map-key scan cachedMapKey wraps, kept here
+// so BenchmarkMapKeyResolution_Uncached can measure it directly.
+func rawMapKeyScan(value reflect.Value, name, camelName string, caseSensitive bool) reflect.Value {
+	for _, mapKey := range value.MapKeys() {
+		k := mapKey.String()
+		if sameName(k, name, caseSensitive) || sameName(k, camelName, caseSensitive) {
+			return value.MapIndex(mapKey)
+		}
+	}
+	return reflect.Value{}
+}
+
+// BenchmarkMapKeyResolution_Uncached resolves a map key by scanning every
+// key on every call, with no cache.
+func BenchmarkMapKeyResolution_Uncached(b *testing.B) {
+	m := reflect.ValueOf(map[string]any{"amount": "500", "recipient": "0x01"})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if v := rawMapKeyScan(m, "amount", "Amount", false); !v.IsValid() {
+			b.Fatal("key not found")
+		}
+	}
+}
+
+// BenchmarkMapKeyResolution_Cached resolves the same map key via
+// cachedMapKey, which scans once per map type and serves every later call
+// from mapKeyCache.
+func BenchmarkMapKeyResolution_Cached(b *testing.B) {
+	m := reflect.ValueOf(map[string]any{"amount": "500", "recipient": "0x01"})
+	if v, ok := cachedMapKey(m, "amount", "Amount", false); !ok || !v.IsValid() {
+		b.Fatal("key not found")
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if v, ok := cachedMapKey(m, "amount", "Amount", false); !ok || !v.IsValid() {
+			b.Fatal("key not found")
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package anypack
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// compileConvert is the compiled counterpart of toArg's abiTyp.T switch: it
+// runs that switch once, at plan-build time, and returns the convertFunc
+// bound to the resulting case so later calls go straight to the matching
+// conversion instead of re-dispatching on abiTyp.T. inputType is the static
+// Go type of the value this leaf will receive, when known (see
+// leafInputType); it lets TupleTy/SliceTy/ArrayTy leaves compile their
+// nested plan up front instead of deferring it to first sight of a value.
+func compileConvert(abiTyp abi.Type, inputType reflect.Type, opts Options) convertFunc {
+	size := abiTyp.Size
+
+	switch abiTyp.T {
+	case abi.IntTy:
+		return func(value reflect.Value, _ Options) (any, bool) {
+			rawValue, base := rawValueAndBase(value)
+			return toAbiInt(rawValue, base, size)
+		}
+
+	case abi.UintTy:
+		return func(value reflect.Value, _ Options) (any, bool) {
+			rawValue, base := rawValueAndBase(value)
+			return toAbiUint(rawValue, base, size)
+		}
+
+	case abi.BoolTy:
+		return func(value reflect.Value, _ Options) (any, bool) {
+			rawValue := value.Interface()
+			strVal, isString := rawValue.(string)
+			if isString && has0xPrefix(strVal) {
+				return strVal == "0x1", strVal == "0x1" || strVal == "0x0"
+			}
+			if isString {
+				return strVal == "true", strVal == "true" || strVal == "false"
+			}
+			b, ok := rawValue.(bool)
+			return b, ok
+		}
+
+	case abi.StringTy:
+		return func(value reflect.Value, opts Options) (any, bool) {
+			strVal, isString := value.Interface().(string)
+			if !isString {
+				return nil, false
+			}
+			if opts.MaxStringLen > 0 && len(strVal) > opts.MaxStringLen {
+				return nil, false
+			}
+			return strVal, true
+		}
+
+	case abi.AddressTy:
+		return func(value reflect.Value, _ Options) (any, bool) {
+			switch v := value.Interface().(type) {
+			case common.Address:
+				return v, true
+			case string:
+				return common.HexToAddress(v), common.IsHexAddress(v)
+			case []byte:
+				return common.BytesToAddress(v), len(v) == 20
+			default:
+				return nil, false
+			}
+		}
+
+	case abi.BytesTy:
+		return func(value reflect.Value, _ Options) (any, bool) {
+			rawValue := value.Interface()
+			if strVal, ok := rawValue.(string); ok && has0xPrefix(strVal) {
+				return common.Hex2Bytes(strVal[2:]), true
+			}
+			bytes, ok := rawValue.([]byte)
+			return bytes, ok
+		}
+
+	case abi.FixedBytesTy:
+		return func(value reflect.Value, opts Options) (any, bool) {
+			rawValue := value.Interface()
+			switch value.Kind() {
+			case reflect.Array:
+				return rawValue, value.Len() == size
+			case reflect.Interface:
+				elem := value.Elem()
+				return rawValue, elem.Kind() == reflect.Array && elem.Len() == size
+			case reflect.String:
+				strVal := rawValue.(string)
+				if !has0xPrefix(strVal) {
+					return nil, false
+				}
+				return toFixedBytes(padFixedBytes(common.Hex2Bytes(strVal[2:]), size, opts.PadFixedBytes), size)
+			default:
+				b, ok := rawValue.([]byte)
+				if !ok {
+					return nil, false
+				}
+				return toFixedBytes(b, size)
+			}
+		}
+
+	case abi.FunctionTy:
+		return func(value reflect.Value, _ Options) (any, bool) {
+			v := value
+			if v.Kind() == reflect.Interface {
+				v = v.Elem()
+			}
+			if v.Kind() != reflect.Array || v.Len() != 24 {
+				return nil, false
+			}
+			return value.Interface(), true
+		}
+
+	case abi.TupleTy:
+		return compileTupleConvert(abiTyp, inputType, opts)
+
+	case abi.SliceTy, abi.ArrayTy:
+		return compileSliceConvert(abiTyp, inputType, opts)
+
+	default:
+		// abi.HashTy and abi.FixedPointTy fall through to here and always
+		// fail: go-ethereum@v1.13.11 never produces either from abi.NewType,
+		// abi.JSON or abi.ParseSelector, and even a hand-built abi.Type with
+		// one of these T values can't reach Arguments.Pack — packElement has
+		// no case for HashTy, and FixedPointTy's typeCheck rejects *big.Int.
+		// There is no supported way to pack them, so they're left
+		// unimplemented rather than shipping a conversion nothing can use.
+		return func(reflect.Value, Options) (any, bool) { return nil, false }
+	}
+}
+
+// rawValueAndBase unwraps value to the any toArg's numeric conversions
+// expect, along with the strconv base implied by a "0x"-prefixed string.
+func rawValueAndBase(value reflect.Value) (any, int) {
+	rawValue := value.Interface()
+	strVal, isString := rawValue.(string)
+	isHex := isString && has0xPrefix(strVal)
+	return rawValue, ternary(isHex, 16, 10)
+}
+
+// tupleArguments turns a TupleTy abi.Type's element types/names into the
+// abi.Arguments shape compilePlan expects, so a tuple's fields compile
+// through the same machinery as a method's top-level arguments.
+func tupleArguments(abiTyp abi.Type) abi.Arguments {
+	args := make(abi.Arguments, len(abiTyp.TupleElems))
+	for i, elemTyp := range abiTyp.TupleElems {
+		args[i] = abi.Argument{Name: abiTyp.TupleRawNames[i], Type: *elemTyp}
+	}
+	return args
+}
+
+// compileTupleConvert compiles the TupleTy case: when inputType statically
+// names a struct or map (the tuple's container type is known up front), its
+// field plan compiles immediately. Otherwise (a tuple nested inside a
+// map[string]any value, whose concrete shape only appears at call time) the
+// field plan is compiled lazily on first sight of a concrete type and
+// cached per type thereafter, so repeat calls against the same shape still
+// skip straight to the cached plan.
+func compileTupleConvert(abiTyp abi.Type, inputType reflect.Type, opts Options) convertFunc {
+	args := tupleArguments(abiTyp)
+
+	if inputType != nil && (inputType.Kind() == reflect.Struct || inputType.Kind() == reflect.Map) {
+		sub := compilePlan(args, inputType, opts)
+		return func(value reflect.Value, opts Options) (any, bool) {
+			return execTuple(abiTyp, sub, value, opts)
+		}
+	}
+
+	var dynamic sync.Map // map[reflect.Type]compiledPlan
+	return func(value reflect.Value, opts Options) (any, bool) {
+		v := value
+		if v.Kind() == reflect.Interface {
+			v = v.Elem()
+		}
+		sub := dynamicSubPlan(&dynamic, args, v.Type(), opts)
+		return execTuple(abiTyp, sub, v, opts)
+	}
+}
+
+// dynamicSubPlan resolves the compiledPlan for typ from cache, compiling
+// and storing it on first sight of typ.
+func dynamicSubPlan(cache *sync.Map, args abi.Arguments, typ reflect.Type, opts Options) compiledPlan {
+	if cached, ok := cache.Load(typ); ok {
+		return cached.(compiledPlan)
+	}
+	sub := compilePlan(args, typ, opts)
+	cache.Store(typ, sub)
+	return sub
+}
+
+// execTuple runs sub against container and assembles the resulting values
+// into abiTyp's go-ethereum-generated tuple struct.
+func execTuple(abiTyp abi.Type, sub compiledPlan, container reflect.Value, opts Options) (any, bool) {
+	tuple := reflect.New(abiTyp.TupleType).Elem()
+	for i, leaf := range sub {
+		v, ok := leaf.run(container, opts)
+		if !ok {
+			return nil, false
+		}
+		tuple.Field(i).Set(reflect.ValueOf(v))
+	}
+	return tuple.Interface(), true
+}
+
+// compileSliceConvert compiles the SliceTy/ArrayTy case. The Go slice type
+// elements pack into is resolved once (cachedSliceType) rather than on
+// every call, and the element converter is itself compiled once via
+// compileConvert; for tuple elements whose concrete type is only known at
+// call time, that converter carries its own per-shape cache (see
+// compileTupleConvert), so it still specializes after the first element of
+// a given shape is seen.
+func compileSliceConvert(abiTyp abi.Type, inputType reflect.Type, opts Options) convertFunc {
+	elemTyp := *abiTyp.Elem
+	goSliceTyp := cachedSliceType(elemTyp)
+
+	var elemInputType reflect.Type
+	if inputType != nil && (inputType.Kind() == reflect.Slice || inputType.Kind() == reflect.Array) {
+		elemInputType = inputType.Elem()
+		if elemInputType.Kind() == reflect.Interface {
+			elemInputType = nil
+		}
+	}
+
+	elem := compiledLeaf{
+		name:    "",
+		access:  identityAccess,
+		convert: compileConvert(elemTyp, elemInputType, opts),
+	}
+
+	return func(value reflect.Value, opts Options) (any, bool) {
+		v := value
+		if v.Kind() == reflect.Interface {
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return nil, false
+		}
+
+		slice := reflect.MakeSlice(goSliceTyp, v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			res, ok := elem.run(v.Index(i), opts)
+			if !ok {
+				return nil, false
+			}
+			slice.Index(i).Set(reflect.ValueOf(res))
+		}
+		return slice.Interface(), true
+	}
+}
@@ -0,0 +1,226 @@
+package anypack
+
+import (
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// TestCachedFieldIndexNegativeResult guards against a prior bug where a
+// cached "not found" result was stored as a nil []int and read back via a
+// bare type assertion, which reports ok=true for a typed nil slice. That
+// made resolveField treat the struct itself as the matched field on the
+// second and later lookups for the same (type, name) pair.
+func TestCachedFieldIndexNegativeResult(t *testing.T) {
+	type noMatch struct {
+		Other string
+	}
+	typ := reflect.TypeOf(noMatch{})
+
+	for i := 0; i < 3; i++ {
+		if _, ok := cachedFieldIndex(typ, "missing", false); ok {
+			t.Fatalf("call %d: cachedFieldIndex unexpectedly found a field", i)
+		}
+	}
+}
+
+// TestToArgTupleMissingFieldStaysMissing reproduces the reviewer's repro at
+// the toArg level: input has no "Params" field for the tuple
+// argument named "params" to resolve onto, but its own fields happen to
+// match the tuple's internal components ("tokenIn"). Before the fix, the
+// first toArg call correctly failed to find "params" on input, but every
+// later call read back the cached negative result as "found at index []",
+// which resolves to input itself — and input's "TokenIn" field then
+// satisfies the tuple's own field lookup, so the call wrongly succeeded.
+func TestToArgTupleMissingFieldStaysMissing(t *testing.T) {
+	type flatSwap struct {
+		TokenIn string
+	}
+
+	typ, err := abi.NewType("tuple", "", []abi.ArgumentMarshaling{
+		{Name: "tokenIn", Type: "address"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := flatSwap{TokenIn: "0x1111111111111111111111111111111111111111"}
+	for i := 0; i < 3; i++ {
+		if _, ok := toArg("params", typ, input, Options{}); ok {
+			t.Fatalf("call %d: toArg unexpectedly matched a struct with no \"Params\" field", i)
+		}
+	}
+}
+
+// TestToArgsPlanNestedTupleFromStruct packs the nested-tuple swap method
+// from a struct input several times, exercising the compile-once path in
+// compileTupleConvert: the struct's field types are known statically, so
+// both tuple levels should compile immediately rather than falling back to
+// the per-call dynamic resolution used for map-rooted tuples.
+func TestToArgsPlanNestedTupleFromStruct(t *testing.T) {
+	inputs := mustMethodInputs(t, nestedTupleSwapABI, "swap")
+	input := swapInput{
+		Params: swapParams{
+			TokenIn:  "0x1111111111111111111111111111111111111111",
+			TokenOut: "0x2222222222222222222222222222222222222222",
+			Fee: swapFee{
+				Amount:    "500",
+				Recipient: "0x3333333333333333333333333333333333333333",
+			},
+		},
+	}
+
+	for i := 0; i < 3; i++ {
+		values, err := toArgs(inputs, input, Options{})
+		if err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+		if len(values) != 1 {
+			t.Fatalf("call %d: len(values) = %d, want 1", i, len(values))
+		}
+	}
+}
+
+// TestToArgsPlanNestedTupleFromMap packs the same nested-tuple swap method
+// from a map[string]any input instead, exercising compileTupleConvert's
+// dynamic branch: the tuple's concrete shape (here, more nested maps) is
+// only known once a value arrives, so the sub-plan is compiled lazily and
+// cached per shape. Run repeatedly so a second call replays the cached
+// sub-plan rather than recompiling it.
+func TestToArgsPlanNestedTupleFromMap(t *testing.T) {
+	inputs := mustMethodInputs(t, nestedTupleSwapABI, "swap")
+	input := map[string]any{
+		"params": map[string]any{
+			"tokenIn":  "0x1111111111111111111111111111111111111111",
+			"tokenOut": "0x2222222222222222222222222222222222222222",
+			"fee": map[string]any{
+				"amount":    "500",
+				"recipient": "0x3333333333333333333333333333333333333333",
+			},
+		},
+	}
+
+	for i := 0; i < 3; i++ {
+		values, err := toArgs(inputs, input, Options{})
+		if err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+		if len(values) != 1 {
+			t.Fatalf("call %d: len(values) = %d, want 1", i, len(values))
+		}
+	}
+}
+
+// TestToArgsPlanFlatMapResolvesTupleFieldsDirectly packs a tuple argument
+// from a map that has no wrapper key matching the tuple's own name, relying
+// on toArg's map fallback: a map-container miss resolves to
+// the container itself, so the tuple's own fields then resolve directly off
+// the flat parent map. The compiled plan's map access must keep that
+// fallback rather than failing outright on the miss.
+func TestToArgsPlanFlatMapResolvesTupleFieldsDirectly(t *testing.T) {
+	inputs := mustMethodInputs(t, nestedTupleSwapABI, "swap")
+	input := map[string]any{
+		"tokenIn":  "0x1111111111111111111111111111111111111111",
+		"tokenOut": "0x2222222222222222222222222222222222222222",
+		"fee": map[string]any{
+			"amount":    "500",
+			"recipient": "0x3333333333333333333333333333333333333333",
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		values, err := toArgs(inputs, input, Options{})
+		if err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+		if len(values) != 1 {
+			t.Fatalf("call %d: len(values) = %d, want 1", i, len(values))
+		}
+	}
+}
+
+// TestToArgsPlanCaseSensitiveKeyedSeparately guards against a compiled plan
+// leaking across Options that change name resolution: the same abi.Arguments
+// and input reflect.Type packed once case-insensitively and once
+// case-sensitively must each resolve correctly rather than reusing the
+// other's cached plan.
+func TestToArgsPlanCaseSensitiveKeyedSeparately(t *testing.T) {
+	args := transferArgs(t)
+	input := map[string]any{
+		"TO":     "0x1111111111111111111111111111111111111111",
+		"AMOUNT": "1000",
+	}
+
+	if _, err := toArgs(args, input, Options{}); err != nil {
+		t.Fatalf("case-insensitive pack failed: %v", err)
+	}
+	if _, err := toArgs(args, input, Options{CaseSensitive: true}); err == nil {
+		t.Fatal("expected case-sensitive pack to fail against mismatched-case map keys")
+	}
+	// Repeat case-insensitive once more to confirm the case-sensitive miss
+	// above didn't clobber the earlier cached plan.
+	if _, err := toArgs(args, input, Options{}); err != nil {
+		t.Fatalf("case-insensitive pack failed after case-sensitive miss: %v", err)
+	}
+}
+
+// TestPlanCacheBoundedForNeverReusedArguments reproduces the pattern
+// PackMethod/PackSignature actually exercise: every call builds a brand-new
+// abi.Arguments backing array, so the pointer-identity plan cache misses on
+// every single call. Without a cap, each of those misses would permanently
+// pin a new cache entry (and the abi.Arguments array it keys on) forever.
+// maxPlanCacheEntries should stop planCache from growing past it.
+func TestPlanCacheBoundedForNeverReusedArguments(t *testing.T) {
+	addrTyp, err := abi.NewType("address", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := map[string]any{"to": "0x1111111111111111111111111111111111111111"}
+
+	for i := 0; i < maxPlanCacheEntries+50; i++ {
+		args := abi.Arguments{{Name: "to", Type: addrTyp}}
+		if _, err := toArgs(args, input, Options{}); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+
+	if size := atomic.LoadInt64(&planCacheSize); size > maxPlanCacheEntries {
+		t.Errorf("planCacheSize = %d, want <= %d", size, maxPlanCacheEntries)
+	}
+}
+
+// TestCachedSliceTypeDistinguishesTupleFieldNames guards against
+// cachedSliceType colliding two tuple[] types that share the same element
+// types but different field names: abi.Type.String() renders both as e.g.
+// "(uint256,address)" with no field names, so keying the slice-type cache
+// on that string would hand one tuple's generated Go slice element type to
+// the other and panic on reflect.Value.Set.
+func TestCachedSliceTypeDistinguishesTupleFieldNames(t *testing.T) {
+	abiA := `[{"name":"a","type":"function","inputs":[{"name":"items","type":"tuple[]","components":[{"name":"amount","type":"uint256"},{"name":"recipient","type":"address"}]}]}]`
+	abiB := `[{"name":"b","type":"function","inputs":[{"name":"items","type":"tuple[]","components":[{"name":"value","type":"uint256"},{"name":"to","type":"address"}]}]}]`
+
+	methodA := mustMethodInputs(t, abiA, "a")
+	methodB := mustMethodInputs(t, abiB, "b")
+
+	inputA := map[string]any{
+		"items": []any{map[string]any{
+			"amount":    "1",
+			"recipient": "0x1111111111111111111111111111111111111111",
+		}},
+	}
+	inputB := map[string]any{
+		"items": []any{map[string]any{
+			"value": "2",
+			"to":    "0x2222222222222222222222222222222222222222",
+		}},
+	}
+
+	if _, err := toArgs(methodA, inputA, Options{}); err != nil {
+		t.Fatalf("pack a: %v", err)
+	}
+	if _, err := toArgs(methodB, inputB, Options{}); err != nil {
+		t.Fatalf("pack b: %v", err)
+	}
+}
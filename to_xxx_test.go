@@ -0,0 +1,65 @@
+package anypack
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// TestToArgUnsupportedTypesRejected covers abi.HashTy and abi.FixedPointTy,
+// which compileConvert always fails rather than converts: go-ethereum@v1.13.11
+// never produces either from abi.NewType/abi.JSON/abi.ParseSelector, and even
+// a hand-built abi.Type carrying one of these T values can't reach
+// Arguments.Pack (packElement has no HashTy case, and FixedPointTy's
+// typeCheck rejects *big.Int), so there is no value these types could ever
+// successfully pack.
+func TestToArgUnsupportedTypesRejected(t *testing.T) {
+	hashTyp, err := abi.NewType("bytes32", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashTyp.T = abi.HashTy
+	if _, ok := toArg("h", hashTyp, "0x1111111111111111111111111111111111111111111111111111111111111111", Options{}); ok {
+		t.Error("toArg unexpectedly succeeded for abi.HashTy")
+	}
+
+	fixedPointTyp := abi.Type{T: abi.FixedPointTy, Size: 2}
+	if _, ok := toArg("f", fixedPointTyp, "1.23", Options{}); ok {
+		t.Error("toArg unexpectedly succeeded for abi.FixedPointTy")
+	}
+}
+
+func TestToArgStringTyMaxLen(t *testing.T) {
+	typ, err := abi.NewType("string", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := toArg("s", typ, "this string is definitely longer than thirty two bytes", Options{}); !ok {
+		t.Error("unbounded string input unexpectedly rejected")
+	}
+
+	if _, ok := toArg("s", typ, "this string is definitely longer than thirty two bytes", Options{MaxStringLen: 32}); ok {
+		t.Error("string input over MaxStringLen unexpectedly accepted")
+	}
+}
+
+func TestToArgFixedBytesTyPad(t *testing.T) {
+	typ, err := abi.NewType("bytes4", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := toArg("b", typ, "0x01", Options{}); ok {
+		t.Error("short hex input unexpectedly accepted without PadFixedBytes")
+	}
+
+	got, ok := toArg("b", typ, "0x01", Options{PadFixedBytes: true})
+	if !ok {
+		t.Fatal("short hex input rejected with PadFixedBytes set")
+	}
+	want := [4]byte{0, 0, 0, 1}
+	if got.([4]byte) != want {
+		t.Errorf("toArg(%q) = %v, want %v", "0x01", got, want)
+	}
+}
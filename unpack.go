@@ -0,0 +1,112 @@
+package anypack
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Unpack decodes ABI-encoded data into a map keyed by argument name, turning
+// go-ethereum's native ABI-typed Go values back into anypack's looser,
+// JSON-friendly shapes. It is the inverse of toArgs: tuples decode to nested
+// maps, and dynamic bytes/string values are returned as 0x-prefixed hex
+// strings when hexBytes is true, or in their native form otherwise.
+func Unpack(abiArgs abi.Arguments, data []byte, hexBytes bool) (map[string]any, error) {
+	raw, err := abiArgs.UnpackValues(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed Unpack: %w", err)
+	}
+
+	values := make(map[string]any, len(abiArgs))
+	for i, arg := range abiArgs {
+		values[arg.Name] = fromArg(arg.Type, raw[i], hexBytes)
+	}
+	return values, nil
+}
+
+// UnpackInto decodes ABI-encoded data and populates dst, a pointer to a
+// struct, resolving fields the same way toArg resolves them when packing:
+// via resolveField, honoring abi/json struct tags before falling back to
+// abi.ToCamelCase(name). opts configures name resolution the same way it
+// does for toArgs, defaulting to the zero Options value.
+func UnpackInto(abiArgs abi.Arguments, data []byte, hexBytes bool, dst any, opts ...Options) error {
+	values, err := Unpack(abiArgs, data, hexBytes)
+	if err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("failed UnpackInto: dst must be a non-nil pointer to struct, got %T", dst)
+	}
+
+	opt := firstOption(opts)
+	elem := rv.Elem()
+	for name, v := range values {
+		field, ok := resolveField(elem, name, opt)
+		if !ok || !field.CanSet() || v == nil {
+			continue
+		}
+		val := reflect.ValueOf(v)
+		if val.Type().AssignableTo(field.Type()) {
+			field.Set(val)
+		}
+	}
+	return nil
+}
+
+// fromArg converts a single go-ethereum ABI-decoded value into anypack's
+// output shape for abiTyp, recursing into tuples and slices/arrays.
+func fromArg(abiTyp abi.Type, value any, hexBytes bool) any {
+	switch abiTyp.T {
+	case abi.TupleTy:
+		return fromTuple(abiTyp, value, hexBytes)
+
+	case abi.SliceTy, abi.ArrayTy:
+		rv := reflect.ValueOf(value)
+		out := make([]any, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			out[i] = fromArg(*abiTyp.Elem, rv.Index(i).Interface(), hexBytes)
+		}
+		return out
+
+	case abi.BytesTy:
+		if !hexBytes {
+			return value
+		}
+		b, _ := value.([]byte)
+		return "0x" + common.Bytes2Hex(b)
+
+	case abi.StringTy:
+		if !hexBytes {
+			return value
+		}
+		s, _ := value.(string)
+		return "0x" + common.Bytes2Hex([]byte(s))
+
+	case abi.FixedBytesTy, abi.FunctionTy:
+		if !hexBytes {
+			return value
+		}
+		rv := reflect.ValueOf(value)
+		b := make([]byte, rv.Len())
+		reflect.Copy(reflect.ValueOf(b), rv)
+		return "0x" + common.Bytes2Hex(b)
+
+	default:
+		return value
+	}
+}
+
+// fromTuple converts an ABI-decoded tuple struct into a map keyed by the
+// tuple's raw component names.
+func fromTuple(abiTyp abi.Type, value any, hexBytes bool) map[string]any {
+	rv := reflect.ValueOf(value)
+	out := make(map[string]any, len(abiTyp.TupleElems))
+	for i, subTyp := range abiTyp.TupleElems {
+		out[abiTyp.TupleRawNames[i]] = fromArg(*subTyp, rv.Field(i).Interface(), hexBytes)
+	}
+	return out
+}
@@ -0,0 +1,167 @@
+package anypack
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func transferArgs(t *testing.T) abi.Arguments {
+	t.Helper()
+	addrTyp, err := abi.NewType("address", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	amountTyp, err := abi.NewType("uint256", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return abi.Arguments{
+		{Name: "to", Type: addrTyp},
+		{Name: "amount", Type: amountTyp},
+	}
+}
+
+func TestUnpackRoundTrip(t *testing.T) {
+	args := transferArgs(t)
+	to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	amount := big.NewInt(1000)
+
+	packed, err := args.Pack(to, amount)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := Unpack(args, packed, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := values["to"].(common.Address); !ok || got != to {
+		t.Errorf("values[\"to\"] = %v, want %v", values["to"], to)
+	}
+	if got, ok := values["amount"].(*big.Int); !ok || got.Cmp(amount) != 0 {
+		t.Errorf("values[\"amount\"] = %v, want %v", values["amount"], amount)
+	}
+}
+
+func TestUnpackTuple(t *testing.T) {
+	tupleTyp, err := abi.NewType("tuple", "", []abi.ArgumentMarshaling{
+		{Name: "amount", Type: "uint256"},
+		{Name: "recipient", Type: "address"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := abi.Arguments{{Name: "fee", Type: tupleTyp}}
+
+	type fee struct {
+		Amount    *big.Int
+		Recipient common.Address
+	}
+	recipient := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	packed, err := args.Pack(fee{Amount: big.NewInt(42), Recipient: recipient})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := Unpack(args, packed, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nested, ok := values["fee"].(map[string]any)
+	if !ok {
+		t.Fatalf("values[\"fee\"] = %T, want map[string]any", values["fee"])
+	}
+	if got, ok := nested["amount"].(*big.Int); !ok || got.Cmp(big.NewInt(42)) != 0 {
+		t.Errorf("fee.amount = %v, want 42", nested["amount"])
+	}
+	if got, ok := nested["recipient"].(common.Address); !ok || got != recipient {
+		t.Errorf("fee.recipient = %v, want %v", nested["recipient"], recipient)
+	}
+}
+
+func TestUnpackHexBytes(t *testing.T) {
+	strTyp, err := abi.NewType("string", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := abi.Arguments{{Name: "note", Type: strTyp}}
+
+	packed, err := args.Pack("hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := Unpack(args, packed, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["note"] != "0x6869" {
+		t.Errorf("values[\"note\"] = %v, want 0x6869", values["note"])
+	}
+}
+
+func TestUnpackInto(t *testing.T) {
+	args := transferArgs(t)
+	to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	amount := big.NewInt(1000)
+
+	packed, err := args.Pack(to, amount)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dst struct {
+		To     common.Address
+		Amount *big.Int
+	}
+	if err := UnpackInto(args, packed, false, &dst); err != nil {
+		t.Fatal(err)
+	}
+	if dst.To != to {
+		t.Errorf("dst.To = %v, want %v", dst.To, to)
+	}
+	if dst.Amount.Cmp(amount) != 0 {
+		t.Errorf("dst.Amount = %v, want %v", dst.Amount, amount)
+	}
+}
+
+func TestUnpackIntoHonorsStructTags(t *testing.T) {
+	args := transferArgs(t)
+	to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	amount := big.NewInt(1000)
+
+	packed, err := args.Pack(to, amount)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dst struct {
+		Dest     common.Address `abi:"to"`
+		Quantity *big.Int       `json:"amount"`
+	}
+	if err := UnpackInto(args, packed, false, &dst); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Dest != to {
+		t.Errorf("dst.Dest = %v, want %v", dst.Dest, to)
+	}
+	if dst.Quantity.Cmp(amount) != 0 {
+		t.Errorf("dst.Quantity = %v, want %v", dst.Quantity, amount)
+	}
+}
+
+func TestUnpackIntoRejectsNonStructPointer(t *testing.T) {
+	args := transferArgs(t)
+	packed, err := args.Pack(common.HexToAddress("0x1111111111111111111111111111111111111111"), big.NewInt(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var notAStruct int
+	if err := UnpackInto(args, packed, false, &notAStruct); err == nil {
+		t.Fatal("expected error for non-struct destination")
+	}
+}
@@ -0,0 +1,16 @@
+package anypack
+
+// has0xPrefix reports whether s begins with "0x" or "0X", mirroring
+// go-ethereum's hexutil convention for distinguishing hex-encoded strings
+// from plain decimal ones.
+func has0xPrefix(s string) bool {
+	return len(s) >= 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X')
+}
+
+// ternary returns a if cond is true, otherwise b.
+func ternary[T any](cond bool, a, b T) T {
+	if cond {
+		return a
+	}
+	return b
+}